@@ -0,0 +1,78 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ContextKey is a context.Context key used by WithContextFields to look up
+// values to attach as log fields.
+type ContextKey any
+
+// WithContextFields returns a Wrapper that, for each key in keys, looks up
+// ctx.Value(key) and if non-nil attaches it to the call as a field (using
+// fmt.Sprintf("%v", key) as the field name) before forwarding to delegate.
+//
+// Unlike RegisterContextExtractor below, this is opt-in per delegate: use it
+// when a particular Wrapper should pull specific, known context keys, rather
+// than every registered extractor.
+func WithContextFields(delegate Wrapper, keys ...ContextKey) Wrapper {
+	return func(ctx context.Context, msg string) {
+		var fields []any
+		for _, key := range keys {
+			if v := ctx.Value(key); v != nil {
+				fields = append(fields, fmt.Sprintf("%v", key), v)
+			}
+		}
+		delegate.Log(ctx, formatWithKV(msg, fields))
+	}
+}
+
+// ContextExtractor pulls key/value pairs (alternating key, value, ...) out
+// of a context.Context, for use with RegisterContextExtractor.
+type ContextExtractor func(ctx context.Context) []any
+
+var contextExtractors sync.Map // map[string]ContextExtractor
+
+// RegisterContextExtractor registers fn under name so that ZapWrapper and
+// ErrorWithSentryWrapper invoke it on every call, merging the key/value
+// pairs it returns into the structured fields of the log entry (and, for
+// ErrorWithSentryWrapper, into the Sentry scope as tags).
+//
+// This is how tracing or edgecontext middleware earlier in the request
+// stack can teach every Wrapper call to carry trace IDs, edge-request IDs,
+// or tenant IDs, so background-goroutine error logs regain the correlation
+// data they'd otherwise lose.
+//
+// Calling RegisterContextExtractor with a name that's already registered
+// overwrites the previous registration. It's meant to be called from init
+// functions; the registry is a sync.Map, so calling it concurrently with
+// logging calls is safe.
+func RegisterContextExtractor(name string, fn func(ctx context.Context) []any) {
+	contextExtractors.Store(name, ContextExtractor(fn))
+}
+
+func extractContextFields(ctx context.Context) []any {
+	var fields []any
+	contextExtractors.Range(func(_, value any) bool {
+		fields = append(fields, value.(ContextExtractor)(ctx)...)
+		return true
+	})
+	return fields
+}
+
+// kvToStringMap is like kvToMap, but stringifies the values too, for
+// handing off to APIs (like Sentry tags) that require map[string]string.
+func kvToStringMap(keysAndValues []any) map[string]string {
+	m := make(map[string]string, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprintf("%v", keysAndValues[i])
+		val := "(MISSING)"
+		if i+1 < len(keysAndValues) {
+			val = fmt.Sprintf("%v", keysAndValues[i+1])
+		}
+		m[key] = val
+	}
+	return m
+}
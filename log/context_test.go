@@ -0,0 +1,57 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+type testContextKey string
+
+func TestWithContextFieldsAttachesPresentKeys(t *testing.T) {
+	var gotMsg string
+	delegate := Wrapper(func(_ context.Context, msg string) {
+		gotMsg = msg
+	})
+
+	key := testContextKey("request-id")
+	wrapper := WithContextFields(delegate, key)
+
+	ctx := context.WithValue(context.Background(), key, "abc-123")
+	wrapper(ctx, "hello")
+
+	if want := `hello request-id=abc-123`; gotMsg != want {
+		t.Fatalf("expected formatted message %q, got %q", want, gotMsg)
+	}
+}
+
+func TestWithContextFieldsSkipsAbsentKeys(t *testing.T) {
+	var gotMsg string
+	delegate := Wrapper(func(_ context.Context, msg string) {
+		gotMsg = msg
+	})
+
+	wrapper := WithContextFields(delegate, testContextKey("missing"))
+	wrapper(context.Background(), "hello")
+
+	if gotMsg != "hello" {
+		t.Fatalf("expected unmodified message when the key is absent, got %q", gotMsg)
+	}
+}
+
+func TestRegisterContextExtractorMergesFields(t *testing.T) {
+	RegisterContextExtractor("test-extractor", func(_ context.Context) []any {
+		return []any{"traced", true}
+	})
+
+	fields := extractContextFields(context.Background())
+
+	var found bool
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "traced" && fields[i+1] == true {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected extractContextFields to include the registered extractor's fields, got %v", fields)
+	}
+}
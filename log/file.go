@@ -0,0 +1,425 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions configures how FileWrapper rotates the file it writes to.
+//
+// path (the argument passed to FileWrapper) is kept as a symlink pointing at
+// whichever rotated file is currently active. The active file is rotated
+// whenever either MaxSize is exceeded or the Pattern-derived file name
+// changes (e.g. a new day starts), and a background janitor deletes rotated
+// files older than MaxAge or beyond MaxFiles.
+//
+// When Pattern is empty, path is a plain file until the first MaxSize
+// rotation, after which it becomes the symlink.
+type RotateOptions struct {
+	// Pattern is a strftime-like pattern (supporting %Y, %m, %d, %H, %M, %S)
+	// used to derive the rotated file name, for example "app.%Y%m%d.log".
+	// A new rotation is triggered whenever the pattern evaluates to a
+	// different name than the currently active file. Empty means no
+	// time-based rotation.
+	Pattern string
+
+	// MaxSize rotates the active file once its size reaches this many bytes.
+	// Zero means no size-based rotation.
+	MaxSize int64
+
+	// MaxAge is how long a rotated file is kept around before the janitor
+	// deletes it. Zero means rotated files are never removed due to age.
+	MaxAge time.Duration
+
+	// MaxFiles is how many rotated files (not counting the active one) are
+	// kept around, oldest deleted first. Zero means no limit based on count.
+	MaxFiles int
+
+	// Compress gzip-compresses a file once it's rotated out as no longer
+	// active.
+	Compress bool
+
+	// JanitorInterval is how often the janitor checks for files to delete.
+	// Defaults to 1 minute when zero.
+	JanitorInterval time.Duration
+}
+
+// FileWrapper returns a Wrapper that writes to path, rotating it according to
+// opts, along with an io.Closer that should be called on graceful shutdown to
+// flush and close the underlying file and stop the janitor goroutine.
+//
+// keysAndValues passed via Logw are formatted into the line the same way
+// StdWrapper does, since a plain file has no structured fields of its own.
+func FileWrapper(path string, opts RotateOptions) (Wrapper, io.Closer, error) {
+	fw, err := newFileWriter(path, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapper := Wrapper(func(_ context.Context, msg string) {
+		fw.writeLine(msg)
+	})
+	return wrapper, fw, nil
+}
+
+type fileWriter struct {
+	link string
+	opts RotateOptions
+
+	mu          sync.Mutex
+	file        *os.File
+	currentName string // the file actually open, possibly currentBase plus a sequence suffix
+	currentBase string // the plain Pattern-derived name, with no sequence suffix
+	seq         int
+	written     int64
+
+	janitorDone chan struct{}
+}
+
+func newFileWriter(path string, opts RotateOptions) (*fileWriter, error) {
+	if opts.JanitorInterval <= 0 {
+		opts.JanitorInterval = time.Minute
+	}
+	fw := &fileWriter{
+		link:        path,
+		opts:        opts,
+		janitorDone: make(chan struct{}),
+	}
+	if err := fw.rotate(time.Now()); err != nil {
+		return nil, err
+	}
+	go fw.runJanitor()
+	return fw, nil
+}
+
+func (fw *fileWriter) targetName(now time.Time) string {
+	if fw.opts.Pattern == "" {
+		return fw.link
+	}
+	return strftime(fw.opts.Pattern, now)
+}
+
+func (fw *fileWriter) writeLine(line string) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	now := time.Now()
+	if fw.currentBase != fw.targetName(now) {
+		_ = fw.rotateLocked(now)
+	}
+	n, err := fmt.Fprintf(fw.file, "%s %s\n", now.Format(time.RFC3339), line)
+	if err != nil {
+		return
+	}
+	fw.written += int64(n)
+	if fw.opts.MaxSize > 0 && fw.written >= fw.opts.MaxSize {
+		_ = fw.rotateLocked(time.Now())
+	}
+}
+
+// rotate is rotateLocked taking the lock itself, used for the initial open.
+func (fw *fileWriter) rotate(now time.Time) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.rotateLocked(now)
+}
+
+func (fw *fileWriter) rotateLocked(now time.Time) error {
+	base := fw.targetName(now)
+	// sameWindow means the Pattern-derived name hasn't changed since the
+	// last rotation (e.g. we're still in the same day), so this rotation
+	// was triggered by MaxSize rather than by Pattern rolling over.
+	sameWindow := base == fw.currentBase
+
+	old := fw.file
+	oldName := fw.currentName
+
+	if !sameWindow {
+		fw.seq = 0
+	} else if oldName == fw.link {
+		// The active file is sitting directly at fw.link as a plain
+		// regular file rather than behind a symlink, which only happens
+		// on the first MaxSize rotation when Pattern is empty (its
+		// target name is fw.link itself, so the initial open skips the
+		// symlink dance below). Below, we're about to os.Remove(fw.link)
+		// and replace it with a symlink pointing at a new file; doing
+		// that while the old data still lives at that exact path would
+		// delete its only directory entry and destroy it. Give the old
+		// file a name of its own first so it survives the swap.
+		if old != nil {
+			old.Close()
+			old = nil
+		}
+		fw.seq++
+		preserved := fmt.Sprintf("%s.%d", base, fw.seq)
+		if err := os.Rename(fw.link, preserved); err != nil {
+			return fmt.Errorf("log: FileWrapper: preserving previous log file: %w", err)
+		}
+		oldName = preserved
+	}
+
+	name := base
+	if sameWindow {
+		// Reusing base as-is would just reopen the same on-disk file in
+		// append mode and reset fw.written to 0 while the file itself
+		// kept growing unbounded, defeating size-based rotation
+		// entirely. Give it a numeric suffix instead so it's actually a
+		// new file.
+		fw.seq++
+		name = fmt.Sprintf("%s.%d", base, fw.seq)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("log: FileWrapper: creating log dir: %w", err)
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("log: FileWrapper: opening log file: %w", err)
+	}
+
+	fw.file = f
+	fw.currentBase = base
+	fw.currentName = name
+	fw.written = 0
+
+	if old != nil {
+		old.Close()
+	}
+	if name != fw.link {
+		// A relative symlink target resolves against the link's own
+		// directory, not the working directory name is relative to.
+		target := name
+		if abs, err := filepath.Abs(name); err == nil {
+			target = abs
+		}
+		_ = os.Remove(fw.link)
+		_ = os.Symlink(target, fw.link)
+	}
+	if oldName != "" && oldName != name && fw.opts.Compress {
+		go compressFile(oldName)
+	}
+	return nil
+}
+
+// Close flushes and closes the active file and stops the janitor goroutine.
+func (fw *fileWriter) Close() error {
+	close(fw.janitorDone)
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.file == nil {
+		return nil
+	}
+	return fw.file.Close()
+}
+
+func (fw *fileWriter) runJanitor() {
+	ticker := time.NewTicker(fw.opts.JanitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fw.janitorDone:
+			return
+		case <-ticker.C:
+			fw.clean()
+		}
+	}
+}
+
+func (fw *fileWriter) clean() {
+	if fw.opts.MaxAge <= 0 && fw.opts.MaxFiles <= 0 {
+		return
+	}
+
+	// Rotated files carry the Pattern-derived name (or path itself when
+	// Pattern is empty), possibly followed by a ".N" sequence suffix and a
+	// ".gz" suffix once compressed.
+	glob := fw.link + ".*"
+	if fw.opts.Pattern != "" {
+		glob = strftimeGlob(fw.opts.Pattern) + "*"
+	}
+	matches, err := filepath.Glob(glob)
+	if err != nil {
+		return
+	}
+
+	fw.mu.Lock()
+	current := fw.currentName
+	fw.mu.Unlock()
+
+	type candidate struct {
+		path    string
+		modTime time.Time
+	}
+	var candidates []candidate
+	for _, m := range matches {
+		if m == current {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, candidate{path: m, modTime: info.ModTime()})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].modTime.Before(candidates[j].modTime)
+	})
+
+	now := time.Now()
+	keep := len(candidates)
+	if fw.opts.MaxFiles > 0 && keep > fw.opts.MaxFiles {
+		keep = fw.opts.MaxFiles
+	}
+	toDelete := len(candidates) - keep
+	for i, c := range candidates {
+		tooOld := fw.opts.MaxAge > 0 && now.Sub(c.modTime) > fw.opts.MaxAge
+		if i < toDelete || tooOld {
+			os.Remove(c.path)
+		}
+	}
+}
+
+func compressFile(path string) {
+	in, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		return
+	}
+	if err := gz.Close(); err != nil {
+		return
+	}
+	os.Remove(path)
+}
+
+var strftimeReplacer = strings.NewReplacer(
+	"%Y", "2006",
+	"%m", "01",
+	"%d", "02",
+	"%H", "15",
+	"%M", "04",
+	"%S", "05",
+)
+
+func strftime(pattern string, t time.Time) string {
+	return t.Format(strftimeReplacer.Replace(pattern))
+}
+
+func strftimeGlob(pattern string) string {
+	replacer := strings.NewReplacer("%Y", "*", "%m", "*", "%d", "*", "%H", "*", "%M", "*", "%S", "*")
+	return replacer.Replace(pattern)
+}
+
+// fileWrapperClosers tracks the io.Closer of every FileWrapper created by the
+// "file:" UnmarshalText scheme, keyed by path. The scheme only has a Wrapper
+// to hand back to UnmarshalText, with nowhere to return the matching
+// io.Closer to; CloseFileWrapper is the other end of that, so config-driven
+// instances can still be flushed and stopped on shutdown.
+var fileWrapperClosers sync.Map // map[string]io.Closer
+
+// CloseFileWrapper closes and forgets the io.Closer for the FileWrapper that
+// the "file:" UnmarshalText scheme created for path, so its file handle gets
+// flushed and closed and its janitor goroutine stopped.
+//
+// Call this during graceful shutdown for every "file:" path used in config.
+// It's a no-op, returning nil, if path was never configured via the "file"
+// scheme (or was already closed).
+func CloseFileWrapper(path string) error {
+	v, ok := fileWrapperClosers.LoadAndDelete(path)
+	if !ok {
+		return nil
+	}
+	return v.(io.Closer).Close()
+}
+
+func init() {
+	RegisterWrapperScheme("file", func(args string) (Wrapper, error) {
+		path, rawQuery, _ := strings.Cut(args, "?")
+		if path == "" {
+			return nil, fmt.Errorf("log.Wrapper.UnmarshalText: file scheme requires a path")
+		}
+
+		opts := RotateOptions{
+			Pattern: path + ".%Y%m%d",
+		}
+		values, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed file query %q: %w", rawQuery, err)
+		}
+		if v := values.Get("maxsize"); v != "" {
+			size, err := parseSize(v)
+			if err != nil {
+				return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed maxsize %q: %w", v, err)
+			}
+			opts.MaxSize = size
+		}
+		if v := values.Get("maxage"); v != "" {
+			age, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed maxage %q: %w", v, err)
+			}
+			opts.MaxAge = age
+		}
+		if v := values.Get("maxfiles"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed maxfiles %q: %w", v, err)
+			}
+			opts.MaxFiles = n
+		}
+		if values.Get("compress") == "gzip" {
+			opts.Compress = true
+		}
+
+		wrapper, closer, err := FileWrapper(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		fileWrapperClosers.Store(path, closer)
+		return wrapper, nil
+	})
+}
+
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "KB"):
+		multiplier = 1 << 10
+		s = strings.TrimSuffix(s, "KB")
+	case strings.HasSuffix(s, "MB"):
+		multiplier = 1 << 20
+		s = strings.TrimSuffix(s, "MB")
+	case strings.HasSuffix(s, "GB"):
+		multiplier = 1 << 30
+		s = strings.TrimSuffix(s, "GB")
+	case strings.HasSuffix(s, "B"):
+		s = strings.TrimSuffix(s, "B")
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileWrapperMaxSizeRotates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	wrapper, closer, err := FileWrapper(path, RotateOptions{MaxSize: 1})
+	if err != nil {
+		t.Fatalf("FileWrapper: %v", err)
+	}
+	defer closer.Close()
+
+	wrapper(context.Background(), "first")
+	wrapper(context.Background(), "second")
+	wrapper(context.Background(), "third")
+
+	matches, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	// The active file (or its symlink) plus at least one rotated-out file
+	// with a numeric suffix, since MaxSize is tiny enough to roll over on
+	// every write.
+	if len(matches) < 2 {
+		t.Fatalf("expected MaxSize to produce multiple on-disk files, got %v", matches)
+	}
+
+	var sawSuffixed bool
+	for _, m := range matches {
+		if filepath.Ext(m) != "" && m != path {
+			sawSuffixed = true
+		}
+	}
+	if !sawSuffixed {
+		t.Fatalf("expected at least one rotated file with a numeric suffix, got %v", matches)
+	}
+}
+
+func TestFileWrapperMaxSizePreservesPriorContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	wrapper, closer, err := FileWrapper(path, RotateOptions{MaxSize: 1})
+	if err != nil {
+		t.Fatalf("FileWrapper: %v", err)
+	}
+	defer closer.Close()
+
+	wrapper(context.Background(), "first")
+	wrapper(context.Background(), "second")
+
+	matches, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+
+	var all string
+	for _, m := range matches {
+		data, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatalf("ReadFile(%q): %v", m, err)
+		}
+		all += string(data)
+	}
+
+	if !strings.Contains(all, "first") {
+		t.Fatalf("expected the pre-rotation write to survive rotation, on-disk files were %v containing %q", matches, all)
+	}
+	if !strings.Contains(all, "second") {
+		t.Fatalf("expected the post-rotation write to be present, on-disk files were %v containing %q", matches, all)
+	}
+}
+
+func TestFileWrapperWritesLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	wrapper, closer, err := FileWrapper(path, RotateOptions{})
+	if err != nil {
+		t.Fatalf("FileWrapper: %v", err)
+	}
+
+	wrapper(context.Background(), "hello world")
+	closer.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if got := string(data); !strings.Contains(got, "hello world") {
+		t.Fatalf("expected written file to contain %q, got %q", "hello world", got)
+	}
+}
+
+func TestCloseFileWrapperIsNoopForUnknownPath(t *testing.T) {
+	if err := CloseFileWrapper(filepath.Join(t.TempDir(), "never-configured.log")); err != nil {
+		t.Fatalf("CloseFileWrapper on unknown path: %v", err)
+	}
+}
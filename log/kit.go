@@ -0,0 +1,118 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	kitlog "github.com/go-kit/log"
+	kitlevel "github.com/go-kit/log/level"
+)
+
+// KitWrapper wraps a go-kit log.Logger into a StructuredWrapper, mapping the
+// (ctx, msg, keysAndValues...) call shape onto
+// logger.Log("msg", msg, keysAndValues...), so keysAndValues reach logger as
+// distinct logfmt "k=v" pairs instead of being flattened into the message
+// text first.
+//
+// Call ToWrapper on the result if you need a plain Wrapper, for example to
+// assign it to DefaultWrapper or a field typed as Wrapper.
+func KitWrapper(logger kitlog.Logger) StructuredWrapper {
+	return func(_ context.Context, msg string, keysAndValues ...any) {
+		keyvals := make([]interface{}, 0, len(keysAndValues)+2)
+		keyvals = append(keyvals, "msg", msg)
+		keyvals = append(keyvals, keysAndValues...)
+		logger.Log(keyvals...)
+	}
+}
+
+// ToKitLogger wraps a Wrapper into a go-kit log.Logger.
+//
+// The context passed to the Wrapper is always context.Background(), since
+// kitlog.Logger.Log has no context parameter to thread one through. A "msg"
+// keyval pair, if present, is used as the Wrapper's msg argument; everything
+// else is passed through as keysAndValues.
+func (w Wrapper) ToKitLogger() kitlog.Logger {
+	return kitlog.LoggerFunc(func(keyvals ...interface{}) error {
+		msg, rest := splitKitMsg(keyvals)
+		w.Logw(context.Background(), msg, rest...)
+		return nil
+	})
+}
+
+func splitKitMsg(keyvals []interface{}) (string, []any) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok || key != "msg" {
+			continue
+		}
+		msg, _ := keyvals[i+1].(string)
+		rest := make([]any, 0, len(keyvals)-2)
+		rest = append(rest, keyvals[:i]...)
+		rest = append(rest, keyvals[i+2:]...)
+		return msg, rest
+	}
+	rest := make([]any, len(keyvals))
+	for i, v := range keyvals {
+		rest[i] = v
+	}
+	return "", rest
+}
+
+func init() {
+	RegisterWrapperScheme("logfmt", func(args string) (Wrapper, error) {
+		lvl := ErrorLevel
+		if args != "" {
+			lvl = Level(strings.ToLower(args))
+		}
+		if lvl == NopLevel {
+			return NopWrapper, nil
+		}
+
+		base := kitlog.NewLogfmtLogger(os.Stderr)
+		filtered := kitlevel.NewFilter(base, kitLevelOption(lvl))
+		logger, ok := kitLeveledLogger(filtered, lvl)
+		if !ok {
+			return nil, fmt.Errorf("log.Wrapper.UnmarshalText: unknown logfmt level %q", args)
+		}
+		return KitWrapper(logger).ToWrapper(), nil
+	})
+}
+
+// kitLevelOption maps lvl to the go-kit/log/level.Option that keeps calls at
+// lvl and above, squelching the rest.
+func kitLevelOption(lvl Level) kitlevel.Option {
+	switch lvl {
+	case DebugLevel:
+		return kitlevel.AllowDebug()
+	case InfoLevel:
+		return kitlevel.AllowInfo()
+	case WarnLevel:
+		return kitlevel.AllowWarn()
+	// go-kit/log/level has no panic or fatal tier of its own; fold both into
+	// its highest tier, error.
+	case ErrorLevel, PanicLevel, FatalLevel:
+		return kitlevel.AllowError()
+	default:
+		return kitlevel.AllowAll()
+	}
+}
+
+// kitLeveledLogger tags logger with the go-kit/log/level function matching
+// lvl, so every call through it carries a real "level" key that kitLevelOption
+// above can filter on, instead of a static string baked in once at startup.
+func kitLeveledLogger(logger kitlog.Logger, lvl Level) (kitlog.Logger, bool) {
+	switch lvl {
+	case DebugLevel:
+		return kitlevel.Debug(logger), true
+	case InfoLevel:
+		return kitlevel.Info(logger), true
+	case WarnLevel:
+		return kitlevel.Warn(logger), true
+	case ErrorLevel, PanicLevel, FatalLevel:
+		return kitlevel.Error(logger), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/log"
+)
+
+func TestKitWrapperLogsMsgAndKVAsDistinctFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := kitlog.NewLogfmtLogger(&buf)
+
+	w := KitWrapper(logger)
+	w(context.Background(), "hello", "key1", "value1")
+
+	got := buf.String()
+	if !strings.Contains(got, "msg=hello") || !strings.Contains(got, "key1=value1") {
+		t.Fatalf("expected msg and kv pairs as distinct logfmt fields, got %q", got)
+	}
+}
+
+func TestWrapperToKitLoggerRoundTrip(t *testing.T) {
+	var gotMsg string
+	w := Wrapper(func(_ context.Context, msg string) {
+		gotMsg = msg
+	})
+
+	kl := w.ToKitLogger()
+	if err := kl.Log("msg", "hello", "key1", "value1"); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+
+	if want := "hello key1=value1"; gotMsg != want {
+		t.Fatalf("expected kv formatted into the message text, got %q", gotMsg)
+	}
+}
+
+func TestSplitKitMsgFindsMsgKey(t *testing.T) {
+	msg, rest := splitKitMsg([]interface{}{"key1", "value1", "msg", "hello", "key2", "value2"})
+	if msg != "hello" {
+		t.Fatalf("expected msg %q, got %q", "hello", msg)
+	}
+	if len(rest) != 4 || rest[0] != "key1" || rest[1] != "value1" || rest[2] != "key2" || rest[3] != "value2" {
+		t.Fatalf("expected remaining keyvals without msg, got %v", rest)
+	}
+}
+
+func TestSplitKitMsgWithoutMsgKey(t *testing.T) {
+	msg, rest := splitKitMsg([]interface{}{"key1", "value1"})
+	if msg != "" {
+		t.Fatalf("expected empty msg when no \"msg\" key is present, got %q", msg)
+	}
+	if len(rest) != 2 || rest[0] != "key1" || rest[1] != "value1" {
+		t.Fatalf("expected all keyvals passed through as rest, got %v", rest)
+	}
+}
+
+func TestLogfmtSchemeAcceptsLevelArg(t *testing.T) {
+	var w Wrapper
+	if err := w.UnmarshalText([]byte("logfmt:warn")); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if w == nil {
+		t.Fatalf("expected a non-nil Wrapper from the logfmt scheme")
+	}
+}
+
+func TestLogfmtSchemeNopLevelReturnsNopWrapper(t *testing.T) {
+	factory, ok := lookupWrapperScheme("logfmt")
+	if !ok {
+		t.Fatalf("expected the logfmt scheme to be registered")
+	}
+	w, err := factory("nop")
+	if err != nil {
+		t.Fatalf("factory: %v", err)
+	}
+	if w == nil {
+		t.Fatalf("expected a non-nil Wrapper even for the nop level")
+	}
+	if reflect.ValueOf(w).Pointer() != reflect.ValueOf(NopWrapper).Pointer() {
+		t.Fatalf("expected the nop level to return NopWrapper")
+	}
+}
+
+func TestLogfmtSchemeUnknownLevelErrors(t *testing.T) {
+	factory, ok := lookupWrapperScheme("logfmt")
+	if !ok {
+		t.Fatalf("expected the logfmt scheme to be registered")
+	}
+	if _, err := factory("not-a-level"); err == nil {
+		t.Fatalf("expected an error for an unknown logfmt level")
+	}
+}
@@ -0,0 +1,193 @@
+package log
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SamplingWrapper returns a Wrapper that forwards roughly rate (between 0 and
+// 1) of calls to delegate, and silently drops the rest.
+//
+// This is meant to sit in front of wrappers like ErrorWithSentryWrapper,
+// which would otherwise forward every call to a dependency that's a well
+// known way to get rate-limited or billed into oblivion when it flaps.
+//
+// An optional Counter can be passed to track how many calls get dropped.
+func SamplingWrapper(delegate Wrapper, rate float64, dropped ...Counter) Wrapper {
+	return func(ctx context.Context, msg string) {
+		if rate >= 1 || rand.Float64() < rate {
+			delegate.Log(ctx, msg)
+			return
+		}
+		bumpDroppedCounters(dropped)
+	}
+}
+
+// RateLimitedWrapper returns a Wrapper that forwards to delegate using a
+// token bucket (perSec refill rate, burst capacity) kept per unique msg, so a
+// single noisy message can't starve out the rest. Calls that don't fit in
+// their message's bucket are dropped.
+//
+// Buckets are tracked in a bounded LRU of about 1024 distinct messages;
+// least-recently-used messages are evicted first.
+//
+// An optional Counter can be passed to track how many calls get dropped.
+func RateLimitedWrapper(delegate Wrapper, perSec float64, burst int, dropped ...Counter) Wrapper {
+	limiter := newRateLimiterLRU(perSec, burst)
+	return func(ctx context.Context, msg string) {
+		if limiter.allow(msg) {
+			delegate.Log(ctx, msg)
+			return
+		}
+		bumpDroppedCounters(dropped)
+	}
+}
+
+func bumpDroppedCounters(counters []Counter) {
+	for _, c := range counters {
+		c.Add(1)
+	}
+}
+
+// rateLimitedWrapperMaxKeys bounds how many distinct messages
+// RateLimitedWrapper tracks a token bucket for at once.
+const rateLimitedWrapperMaxKeys = 1024
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	perSec float64
+	burst  float64
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.perSec
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type rateLimiterEntry struct {
+	key    string
+	bucket *tokenBucket
+}
+
+// rateLimiterLRU keeps a bounded set of per-key token buckets, evicting the
+// least-recently-used key once the set grows past its cap.
+type rateLimiterLRU struct {
+	mu      sync.Mutex
+	perSec  float64
+	burst   float64
+	order   *list.List
+	buckets map[string]*list.Element
+}
+
+func newRateLimiterLRU(perSec float64, burst int) *rateLimiterLRU {
+	return &rateLimiterLRU{
+		perSec:  perSec,
+		burst:   float64(burst),
+		order:   list.New(),
+		buckets: make(map[string]*list.Element),
+	}
+}
+
+func (l *rateLimiterLRU) allow(key string) bool {
+	l.mu.Lock()
+	el, ok := l.buckets[key]
+	if ok {
+		l.order.MoveToFront(el)
+	} else {
+		el = l.order.PushFront(&rateLimiterEntry{
+			key: key,
+			bucket: &tokenBucket{
+				tokens: l.burst,
+				last:   time.Now(),
+				perSec: l.perSec,
+				burst:  l.burst,
+			},
+		})
+		l.buckets[key] = el
+		if l.order.Len() > rateLimitedWrapperMaxKeys {
+			oldest := l.order.Back()
+			l.order.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*rateLimiterEntry).key)
+		}
+	}
+	bucket := el.Value.(*rateLimiterEntry).bucket
+	l.mu.Unlock()
+
+	return bucket.allow()
+}
+
+// applyWrapperModifier parses a single "|"-separated modifier (e.g.
+// "sample=0.1" or "rate=5/s,burst=10") from Wrapper.UnmarshalText and wraps
+// wrapper accordingly.
+func applyWrapperModifier(wrapper Wrapper, mod string) (Wrapper, error) {
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(mod, ",") {
+		key, val, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed modifier %q", mod)
+		}
+		fields[key] = val
+	}
+
+	if v, ok := fields["sample"]; ok {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed sample rate %q: %w", v, err)
+		}
+		return SamplingWrapper(wrapper, rate), nil
+	}
+
+	if v, ok := fields["rate"]; ok {
+		perSec, err := parsePerSec(v)
+		if err != nil {
+			return nil, err
+		}
+		burst := int(perSec)
+		if burst < 1 {
+			burst = 1
+		}
+		if b, ok := fields["burst"]; ok {
+			burst, err = strconv.Atoi(b)
+			if err != nil {
+				return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed burst %q: %w", b, err)
+			}
+		}
+		return RateLimitedWrapper(wrapper, perSec, burst), nil
+	}
+
+	return nil, fmt.Errorf("log.Wrapper.UnmarshalText: unsupported modifier %q", mod)
+}
+
+// parsePerSec parses a rate like "5/s" into calls-per-second.
+func parsePerSec(s string) (float64, error) {
+	n, unit, ok := strings.Cut(s, "/")
+	if !ok || unit != "s" {
+		return 0, fmt.Errorf("log.Wrapper.UnmarshalText: malformed rate %q, expected format like \"5/s\"", s)
+	}
+	perSec, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0, fmt.Errorf("log.Wrapper.UnmarshalText: malformed rate %q: %w", s, err)
+	}
+	return perSec, nil
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+type testCounter struct {
+	n atomic.Int64
+}
+
+func (c *testCounter) Add(delta float64) {
+	c.n.Add(int64(delta))
+}
+
+func TestSamplingWrapperRateZeroDropsEverything(t *testing.T) {
+	var forwarded int
+	delegate := Wrapper(func(_ context.Context, _ string) {
+		forwarded++
+	})
+	var dropped testCounter
+
+	wrapper := SamplingWrapper(delegate, 0, &dropped)
+	for i := 0; i < 10; i++ {
+		wrapper(context.Background(), "msg")
+	}
+
+	if forwarded != 0 {
+		t.Fatalf("expected rate 0 to forward nothing, forwarded %d", forwarded)
+	}
+	if dropped.n.Load() != 10 {
+		t.Fatalf("expected 10 dropped calls counted, got %d", dropped.n.Load())
+	}
+}
+
+func TestSamplingWrapperRateOneForwardsEverything(t *testing.T) {
+	var forwarded int
+	delegate := Wrapper(func(_ context.Context, _ string) {
+		forwarded++
+	})
+
+	wrapper := SamplingWrapper(delegate, 1)
+	for i := 0; i < 10; i++ {
+		wrapper(context.Background(), "msg")
+	}
+
+	if forwarded != 10 {
+		t.Fatalf("expected rate 1 to forward everything, forwarded %d", forwarded)
+	}
+}
+
+func TestRateLimitedWrapperDropsBeyondBurst(t *testing.T) {
+	var forwarded int
+	delegate := Wrapper(func(_ context.Context, _ string) {
+		forwarded++
+	})
+	var dropped testCounter
+
+	// perSec of 0 means the bucket never refills after its initial burst, so
+	// everything past the first burst calls is dropped.
+	wrapper := RateLimitedWrapper(delegate, 0, 3, &dropped)
+	for i := 0; i < 5; i++ {
+		wrapper(context.Background(), "same message")
+	}
+
+	if forwarded != 3 {
+		t.Fatalf("expected burst of 3 to be forwarded, forwarded %d", forwarded)
+	}
+	if dropped.n.Load() != 2 {
+		t.Fatalf("expected 2 calls dropped past the burst, got %d", dropped.n.Load())
+	}
+}
+
+func TestRateLimitedWrapperTracksBucketsPerMessage(t *testing.T) {
+	var forwarded int
+	delegate := Wrapper(func(_ context.Context, _ string) {
+		forwarded++
+	})
+
+	wrapper := RateLimitedWrapper(delegate, 0, 1)
+	wrapper(context.Background(), "a")
+	wrapper(context.Background(), "b")
+
+	if forwarded != 2 {
+		t.Fatalf("expected distinct messages to each get their own bucket, forwarded %d", forwarded)
+	}
+}
+
+func TestRateLimiterLRUEvictsOldestKey(t *testing.T) {
+	lru := newRateLimiterLRU(0, 1)
+	for i := 0; i < rateLimitedWrapperMaxKeys+1; i++ {
+		lru.allow(strconv.Itoa(i))
+	}
+	if lru.order.Len() != rateLimitedWrapperMaxKeys {
+		t.Fatalf("expected LRU to stay bounded at %d keys, has %d", rateLimitedWrapperMaxKeys, lru.order.Len())
+	}
+	if _, ok := lru.buckets["0"]; ok {
+		t.Fatalf("expected the oldest key to have been evicted")
+	}
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"fmt"
+	stdlog "log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// wrapperFactory builds a Wrapper from the part of a Wrapper.UnmarshalText
+// string that follows the scheme name, e.g. the "level:k1=v1,k2=v2" part of
+// "zap:level:k1=v1,k2=v2".
+type wrapperFactory func(args string) (Wrapper, error)
+
+var wrapperSchemes sync.Map // map[string]wrapperFactory
+
+// RegisterWrapperScheme registers a scheme that Wrapper.UnmarshalText can
+// build a Wrapper from, in the form of "name" or "name:args".
+//
+// This is how baseplate.go registers its own built-in "nop", "std", "zap",
+// "sentry", "file", and "logfmt" schemes, and it's exported so other
+// projects can register their own (for example "logrus:level") without
+// having to fork or wrap the Wrapper type.
+//
+// Calling RegisterWrapperScheme with a name that's already registered
+// overwrites the previous registration. It's meant to be called from init
+// functions; the registry is a sync.Map, so calling it concurrently with
+// Wrapper.UnmarshalText is safe.
+func RegisterWrapperScheme(name string, factory func(args string) (Wrapper, error)) {
+	wrapperSchemes.Store(name, wrapperFactory(factory))
+}
+
+func lookupWrapperScheme(name string) (wrapperFactory, bool) {
+	factory, ok := wrapperSchemes.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return factory.(wrapperFactory), true
+}
+
+func init() {
+	RegisterWrapperScheme("nop", func(_ string) (Wrapper, error) {
+		return NopWrapper, nil
+	})
+
+	RegisterWrapperScheme("std", func(_ string) (Wrapper, error) {
+		return StdWrapper(stdlog.New(os.Stderr, "", stdlog.LstdFlags)), nil
+	})
+
+	RegisterWrapperScheme("sentry", func(_ string) (Wrapper, error) {
+		return ErrorWithSentryWrapper().ToWrapper(), nil
+	})
+
+	RegisterWrapperScheme("zap", func(args string) (Wrapper, error) {
+		if args == "" {
+			return ZapWrapper(ZapWrapperArgs{Level: Level("")}).ToWrapper(), nil
+		}
+
+		split := strings.Split(args, ":")
+		if len(split) > 2 {
+			return nil, fmt.Errorf(`log.Wrapper.UnmarshalText: malformed input: too many ":": %q`, args)
+		}
+		var pairs map[string]interface{}
+		if len(split) > 1 {
+			kvs := strings.Split(split[1], ",")
+			pairs = make(map[string]interface{}, len(kvs))
+			for _, kv := range kvs {
+				kv = strings.TrimSpace(kv)
+				index := strings.Index(kv, "=")
+				if index < 0 {
+					return nil, fmt.Errorf(`log.Wrapper.UnmarshalText: malformed input: no "=" in kv pair %q`, kv)
+				}
+				key := kv[:index]
+				val := kv[index+1:]
+				if _, ok := pairs[key]; ok {
+					return nil, fmt.Errorf("log.Wrapper.UnmarshalText: malformed input: key %q appeared at least twice", key)
+				}
+				pairs[key] = val
+			}
+		}
+		return ZapWrapper(ZapWrapperArgs{
+			Level:   Level(strings.ToLower(split[0])),
+			KVPairs: pairs,
+		}).ToWrapper(), nil
+	})
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisterWrapperSchemeRoundTrip(t *testing.T) {
+	var called []string
+	RegisterWrapperScheme("test-echo", func(args string) (Wrapper, error) {
+		return func(_ context.Context, msg string) {
+			called = append(called, args+":"+msg)
+		}, nil
+	})
+
+	var w Wrapper
+	if err := w.UnmarshalText([]byte("test-echo:hello")); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	w(context.Background(), "msg")
+
+	if len(called) != 1 || called[0] != "hello:msg" {
+		t.Fatalf("expected registered scheme to be used, got %v", called)
+	}
+}
+
+func TestUnmarshalTextUnknownSchemeErrors(t *testing.T) {
+	var w Wrapper
+	if err := w.UnmarshalText([]byte("does-not-exist")); err == nil {
+		t.Fatalf("expected an error for an unregistered scheme")
+	}
+}
+
+func TestUnmarshalTextZapTooManyColonsErrors(t *testing.T) {
+	var w Wrapper
+	err := w.UnmarshalText([]byte("zap:info:key1=value1:extra"))
+	if err == nil {
+		t.Fatalf("expected an error for a malformed zap args string with extra colons")
+	}
+}
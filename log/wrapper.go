@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	stdlog "log"
-	"os"
 	"strings"
 	"testing"
 
@@ -21,7 +20,7 @@ import (
 // 1. When using nil-safe calls on log.Wrapper on a nil log.Wrapper.
 //
 // 2. When unmarshaling from text (yaml) and the text is empty.
-var DefaultWrapper Wrapper = ErrorWithSentryWrapper()
+var DefaultWrapper Wrapper = ErrorWithSentryWrapper().ToWrapper()
 
 // Wrapper defines a simple interface to wrap logging functions.
 //
@@ -77,6 +76,14 @@ var DefaultWrapper Wrapper = ErrorWithSentryWrapper()
 // Not all Wrapper implementations take advantage of context object passed in,
 // but the caller should always pass it into Wrapper if they already have one,
 // or just use context.Background() if they don't have one.
+//
+// Wrapper's signature is deliberately kept at message-only, so that every
+// existing call site and every existing implementation keeps compiling
+// unchanged. Callers that want to attach structured key/value pairs to a
+// particular call should use the Logw method below instead of calling w
+// directly; implementations that want to receive those pairs natively
+// (instead of having them formatted into the message text) should be built
+// as a StructuredWrapper and bridged back down with ToWrapper.
 type Wrapper func(ctx context.Context, msg string)
 
 // Log is the nil-safe way of calling a log.Wrapper.
@@ -89,6 +96,20 @@ func (w Wrapper) Log(ctx context.Context, msg string) {
 	w(ctx, msg)
 }
 
+// Logw is the nil-safe way of calling a log.Wrapper with structured
+// keysAndValues (alternating key, value, key, value, ...) attached.
+//
+// Since Wrapper itself has nowhere to put structured fields, they are
+// formatted into the message text before w is called. Implementations that
+// want keysAndValues natively (e.g. to thread them into Zap or Sentry) should
+// be a StructuredWrapper instead, which callers can invoke directly.
+func (w Wrapper) Logw(ctx context.Context, msg string, keysAndValues ...any) {
+	if w == nil {
+		w = DefaultWrapper
+	}
+	w(ctx, formatWithKV(msg, keysAndValues))
+}
+
 // ToThriftLogger wraps Wrapper into thrift.Logger.
 func (w Wrapper) ToThriftLogger() thrift.Logger {
 	if w == nil {
@@ -100,11 +121,48 @@ func (w Wrapper) ToThriftLogger() thrift.Logger {
 	}
 }
 
+// StructuredWrapper is like Wrapper, except it also accepts keysAndValues
+// (alternating key, value, key, value, ...) that an implementation backed by
+// a structured logging library can thread through natively, instead of
+// having them formatted into the message text. It mirrors what ZapWrapper
+// already builds on top of zap's SugaredLogger, and is analogous to the
+// go-kit Logger.Log(keyvals ...interface{}) interface.
+//
+// StructuredWrapper is additive: it's a distinct type from Wrapper, so
+// existing Wrapper implementations and call sites are unaffected. Use
+// ToWrapper to get a plain Wrapper out of one, for call sites (like
+// DefaultWrapper, or a thrift.Logger) that only know the message-only shape.
+type StructuredWrapper func(ctx context.Context, msg string, keysAndValues ...any)
+
+// Logw is the nil-safe way of calling a log.StructuredWrapper.
+//
+// If w is nil, DefaultWrapper will be used instead (formatting keysAndValues
+// into the message text, since DefaultWrapper is a plain Wrapper).
+func (w StructuredWrapper) Logw(ctx context.Context, msg string, keysAndValues ...any) {
+	if w == nil {
+		DefaultWrapper.Logw(ctx, msg, keysAndValues...)
+		return
+	}
+	w(ctx, msg, keysAndValues...)
+}
+
+// ToWrapper collapses a StructuredWrapper down to a plain Wrapper, for call
+// sites that only know the message-only shape. Since Wrapper.Log never has
+// keysAndValues to pass along, none are lost by doing this; it's only
+// callers that keep hold of the StructuredWrapper and call Logw on it
+// directly that get the native, structured behavior.
+func (w StructuredWrapper) ToWrapper() Wrapper {
+	return func(ctx context.Context, msg string) {
+		w(ctx, msg)
+	}
+}
+
 // UnmarshalText implements encoding.TextUnmarshaler.
 //
 // It makes Wrapper possible to be used directly in yaml and other config files.
 //
-// Please note that this currently only support limited implementations:
+// The text is either just a scheme name ("nop"), or a scheme name followed by
+// a ":" and scheme-specific args ("zap:error"). Built-in schemes:
 //
 // - empty: DefaultWrapper.
 //
@@ -122,58 +180,51 @@ func (w Wrapper) ToThriftLogger() thrift.Logger {
 //
 // - "sentry": ErrorWithSentryWrapper.
 //
-// See the example on how to extend it to support other implementations.
+// - "file:/path?maxsize=100MB&maxage=24h&maxfiles=7&compress=gzip": FileWrapper
+// rotating path daily, with the query part optional. See CloseFileWrapper.
+//
+// - "logfmt" or "logfmt:level": KitWrapper writing logfmt lines to stderr, on
+// Error level by default.
+//
+// Other schemes can be added via RegisterWrapperScheme, without having to
+// fork or wrap Wrapper.
+//
+// The scheme can optionally be followed by one or more "|modifier=value"
+// suffixes that wrap the resulting Wrapper, for example
+// "sentry|sample=0.1" (SamplingWrapper) or "sentry|rate=5/s" (
+// RateLimitedWrapper, optionally "|rate=5/s,burst=10"). See
+// SamplingWrapper and RateLimitedWrapper for details.
 func (w *Wrapper) UnmarshalText(text []byte) error {
 	s := string(text)
-
-	// Special handling for "zap:level" case
-	const zapLevelPrefix = "zap:"
-	if strings.HasPrefix(s, zapLevelPrefix) {
-		split := strings.Split(s, ":")
-		if len(split) > 3 {
-			return fmt.Errorf(`log.Wrapper.UnmarshalText: malformed input: too many ":": %q`, s)
-		}
-		var pairs map[string]interface{}
-		if len(split) > 2 {
-			kvs := strings.Split(split[2], ",")
-			pairs = make(map[string]interface{}, len(kvs))
-			for _, kv := range kvs {
-				kv = strings.TrimSpace(kv)
-				index := strings.Index(kv, "=")
-				if index < 0 {
-					return fmt.Errorf(`log.Wrapper.UnmarshalText: malformed input: no "=" in kv pair %q`, kv)
-				}
-				key := kv[:index]
-				val := kv[index+1:]
-				if _, ok := pairs[key]; ok {
-					return fmt.Errorf("log.Wrapper.UnmarshalText: malformed input: key %q appeared at least twice", key)
-				}
-				pairs[key] = val
-			}
-		}
-		*w = ZapWrapper(ZapWrapperArgs{
-			Level:   Level(strings.ToLower(split[1])),
-			KVPairs: pairs,
-		})
+	if s == "" {
+		*w = DefaultWrapper
 		return nil
 	}
 
-	switch s {
-	default:
+	segments := strings.Split(s, "|")
+	base := segments[0]
+	name, args := base, ""
+	if index := strings.Index(base, ":"); index >= 0 {
+		name, args = base[:index], base[index+1:]
+	}
+
+	factory, ok := lookupWrapperScheme(name)
+	if !ok {
 		return fmt.Errorf("unsupported log.Wrapper config: %q", text)
-	case "":
-		*w = DefaultWrapper
-	case "nop":
-		*w = NopWrapper
-	case "std":
-		*w = StdWrapper(stdlog.New(os.Stderr, "", stdlog.LstdFlags))
-	case "zap":
-		*w = ZapWrapper(ZapWrapperArgs{
-			Level: Level(""),
-		})
-	case "sentry":
-		*w = ErrorWithSentryWrapper()
 	}
+	wrapper, err := factory(args)
+	if err != nil {
+		return err
+	}
+
+	for _, mod := range segments[1:] {
+		wrapper, err = applyWrapperModifier(wrapper, mod)
+		if err != nil {
+			return err
+		}
+	}
+
+	*w = wrapper
 	return nil
 }
 
@@ -190,6 +241,11 @@ func WrapToThriftLogger(w Wrapper) thrift.Logger {
 // The zero value of log.Wrapper is essentially a NopWrapper.
 func NopWrapper(ctx context.Context, msg string) {}
 
+// NopStructuredWrapper is a StructuredWrapper implementation that does
+// nothing. It's the StructuredWrapper counterpart of NopWrapper, used by
+// ZapWrapper(ZapWrapperArgs{Level: NopLevel}).
+func NopStructuredWrapper(ctx context.Context, msg string, keysAndValues ...any) {}
+
 // StdWrapper wraps stdlib log package into a Wrapper.
 func StdWrapper(logger *stdlog.Logger) Wrapper {
 	if logger == nil {
@@ -210,16 +266,55 @@ func TestWrapper(tb testing.TB) Wrapper {
 	}
 }
 
+// formatWithKV appends keysAndValues (alternating key, value, ...) to msg as
+// "key=value" pairs, for Wrapper implementations that have no structured
+// fields of their own to put them in.
+func formatWithKV(msg string, keysAndValues []any) string {
+	if len(keysAndValues) == 0 {
+		return msg
+	}
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := keysAndValues[i]
+		var val any = "(MISSING)"
+		if i+1 < len(keysAndValues) {
+			val = keysAndValues[i+1]
+		}
+		fmt.Fprintf(&sb, " %v=%v", key, val)
+	}
+	return sb.String()
+}
+
+// kvToMap converts keysAndValues (alternating key, value, ...) into a
+// map[string]interface{}, for Wrapper implementations (like
+// ErrorWithSentryWrapper) that hand fields off to a library expecting a map.
+func kvToMap(keysAndValues []any) map[string]interface{} {
+	m := make(map[string]interface{}, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprintf("%v", keysAndValues[i])
+		var val any = "(MISSING)"
+		if i+1 < len(keysAndValues) {
+			val = keysAndValues[i+1]
+		}
+		m[key] = val
+	}
+	return m
+}
+
 // ZapWrapperArgs defines the args used in ZapWrapper.
 type ZapWrapperArgs struct {
 	Level   Level
 	KVPairs map[string]interface{}
 }
 
-// ZapWrapper wraps zap log package into a Wrapper.
-func ZapWrapper(args ZapWrapperArgs) Wrapper {
+// ZapWrapper wraps zap log package into a StructuredWrapper.
+//
+// Call ToWrapper on the result if you need a plain Wrapper, for example to
+// assign it to DefaultWrapper or a field typed as Wrapper.
+func ZapWrapper(args ZapWrapperArgs) StructuredWrapper {
 	if args.Level == NopLevel {
-		return NopWrapper
+		return NopStructuredWrapper
 	}
 
 	kv := make([]interface{}, 0, len(args.KVPairs)*2)
@@ -227,7 +322,7 @@ func ZapWrapper(args ZapWrapperArgs) Wrapper {
 		kv = append(kv, k, v)
 	}
 
-	return func(ctx context.Context, msg string) {
+	return func(ctx context.Context, msg string, keysAndValues ...any) {
 		logger := C(ctx)
 		// For unknown values, fallback to info level.
 		f := logger.Infow
@@ -243,33 +338,53 @@ func ZapWrapper(args ZapWrapperArgs) Wrapper {
 		case FatalLevel:
 			f = logger.Fatalw
 		}
-		f(msg, kv...)
+		extra := extractContextFields(ctx)
+		if len(extra) == 0 && len(keysAndValues) == 0 {
+			f(msg, kv...)
+			return
+		}
+		allKV := append(append([]interface{}{}, kv...), extra...)
+		f(msg, append(allKV, keysAndValues...)...)
 	}
 }
 
-// ErrorWithSentryWrapper is a Wrapper implementation that both use Zap logger
-// to log at error level, and also send the message to Sentry.
+// ErrorWithSentryWrapper is a StructuredWrapper implementation that both use
+// Zap logger to log at error level, and also send the message to Sentry.
 //
 // In most cases this should be the one used to pass into Baseplate.go libraries
-// expecting a log.Wrapper.
+// expecting a log.Wrapper (via ToWrapper).
 // If the service didn't configure sentry,
 // then this wrapper is essentially the same as log.ZapWrapper(log.ErrorLevel).
 //
 // Note that this should not be used as the logger set into thrift.TSimpleServer,
 // as that would use the logger to log network I/O errors,
 // which would be too spammy to be sent to Sentry.
-// For this reason, it's returning a Wrapper instead of being a Wrapper itself,
-// thus forcing an extra typecasting to be used as a thrift.Logger.
-func ErrorWithSentryWrapper() Wrapper {
-	return func(ctx context.Context, msg string) {
-		C(ctx).Error(msg)
+// For this reason, it's returning a StructuredWrapper instead of being one
+// itself, thus forcing an extra typecasting to be used as a thrift.Logger.
+func ErrorWithSentryWrapper() StructuredWrapper {
+	return func(ctx context.Context, msg string, keysAndValues ...any) {
+		ctxFields := extractContextFields(ctx)
+
+		C(ctx).Errorw(msg, append(append([]interface{}{}, ctxFields...), keysAndValues...)...)
 
 		err := errors.New(msg)
-		if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub := sentry.GetHubFromContext(ctx)
+		if hub == nil {
+			hub = sentry.CurrentHub()
+		}
+		if len(keysAndValues) == 0 && len(ctxFields) == 0 {
 			hub.CaptureException(err)
-		} else {
-			sentry.CaptureException(err)
+			return
 		}
+		hub.WithScope(func(scope *sentry.Scope) {
+			if len(keysAndValues) > 0 {
+				scope.SetExtras(kvToMap(keysAndValues))
+			}
+			if len(ctxFields) > 0 {
+				scope.SetTags(kvToStringMap(ctxFields))
+			}
+			hub.CaptureException(err)
+		})
 	}
 }
 
@@ -311,6 +426,22 @@ func CounterWrapper(delegate Wrapper, counter Counter) Wrapper {
 	}
 }
 
+// StructuredCounterWrapper is the StructuredWrapper counterpart of
+// CounterWrapper: it increases counter by 1 then calls delegate.Logw, so
+// keysAndValues keep flowing into delegate natively instead of being
+// formatted into the message text.
+//
+// Use this instead of CounterWrapper when delegate is a StructuredWrapper
+// (for example ZapWrapper or ErrorWithSentryWrapper) and the kv pairs passed
+// via Logw need to survive the trip through the counter.
+func StructuredCounterWrapper(delegate StructuredWrapper, counter Counter) StructuredWrapper {
+	return func(ctx context.Context, msg string, keysAndValues ...any) {
+		counter.Add(1)
+		delegate.Logw(ctx, msg, keysAndValues...)
+	}
+}
+
 var (
-	_ Wrapper = NopWrapper
+	_ Wrapper           = NopWrapper
+	_ StructuredWrapper = NopStructuredWrapper
 )
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWrapperLogwFormatsKVIntoMessage(t *testing.T) {
+	var got string
+	w := Wrapper(func(_ context.Context, msg string) {
+		got = msg
+	})
+
+	w.Logw(context.Background(), "hello", "key1", "value1")
+
+	if want := "hello key1=value1"; got != want {
+		t.Fatalf("expected formatted message %q, got %q", want, got)
+	}
+}
+
+func TestWrapperLogwNilFallsBackToDefaultWrapper(t *testing.T) {
+	var called bool
+	original := DefaultWrapper
+	DefaultWrapper = func(_ context.Context, _ string) {
+		called = true
+	}
+	defer func() { DefaultWrapper = original }()
+
+	var w Wrapper
+	w.Logw(context.Background(), "hello")
+
+	if !called {
+		t.Fatalf("expected a nil Wrapper's Logw to fall back to DefaultWrapper")
+	}
+}
+
+func TestStructuredWrapperLogwPassesKVNatively(t *testing.T) {
+	var gotMsg string
+	var gotKV []any
+	w := StructuredWrapper(func(_ context.Context, msg string, keysAndValues ...any) {
+		gotMsg = msg
+		gotKV = keysAndValues
+	})
+
+	w.Logw(context.Background(), "hello", "key1", "value1")
+
+	if gotMsg != "hello" || len(gotKV) != 2 || gotKV[0] != "key1" || gotKV[1] != "value1" {
+		t.Fatalf("expected kv to reach the StructuredWrapper natively, got msg=%q kv=%v", gotMsg, gotKV)
+	}
+}
+
+func TestStructuredWrapperLogwNilFallsBackToDefaultWrapper(t *testing.T) {
+	var gotMsg string
+	original := DefaultWrapper
+	DefaultWrapper = func(_ context.Context, msg string) {
+		gotMsg = msg
+	}
+	defer func() { DefaultWrapper = original }()
+
+	var w StructuredWrapper
+	w.Logw(context.Background(), "hello", "key1", "value1")
+
+	if want := "hello key1=value1"; gotMsg != want {
+		t.Fatalf("expected a nil StructuredWrapper's Logw to format kv via DefaultWrapper, got %q", gotMsg)
+	}
+}
+
+func TestStructuredWrapperToWrapperForwardsMsg(t *testing.T) {
+	var gotMsg string
+	sw := StructuredWrapper(func(_ context.Context, msg string, _ ...any) {
+		gotMsg = msg
+	})
+
+	w := sw.ToWrapper()
+	w(context.Background(), "hello")
+
+	if gotMsg != "hello" {
+		t.Fatalf("expected ToWrapper's result to still reach the delegate, got %q", gotMsg)
+	}
+}
+
+func TestFormatWithKV(t *testing.T) {
+	cases := []struct {
+		name string
+		msg  string
+		kv   []any
+		want string
+	}{
+		{"no kv", "hello", nil, "hello"},
+		{"one pair", "hello", []any{"key1", "value1"}, "hello key1=value1"},
+		{"odd kv gets MISSING", "hello", []any{"key1"}, "hello key1=(MISSING)"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := formatWithKV(c.msg, c.kv); got != c.want {
+				t.Errorf("formatWithKV(%q, %v) = %q, want %q", c.msg, c.kv, got, c.want)
+			}
+		})
+	}
+}
+
+func TestKVToMap(t *testing.T) {
+	m := kvToMap([]any{"key1", "value1", "key2"})
+	if m["key1"] != "value1" {
+		t.Errorf("expected key1 to map to value1, got %v", m["key1"])
+	}
+	if m["key2"] != "(MISSING)" {
+		t.Errorf("expected a dangling key to map to (MISSING), got %v", m["key2"])
+	}
+}
+
+func TestStructuredCounterWrapperIncrementsAndForwardsKV(t *testing.T) {
+	var gotMsg string
+	var gotKV []any
+	delegate := StructuredWrapper(func(_ context.Context, msg string, keysAndValues ...any) {
+		gotMsg = msg
+		gotKV = keysAndValues
+	})
+	var counter testCounter
+
+	wrapper := StructuredCounterWrapper(delegate, &counter)
+	wrapper(context.Background(), "hello", "key1", "value1")
+
+	if counter.n.Load() != 1 {
+		t.Fatalf("expected counter to be incremented once, got %d", counter.n.Load())
+	}
+	if gotMsg != "hello" || len(gotKV) != 2 || gotKV[0] != "key1" || gotKV[1] != "value1" {
+		t.Fatalf("expected kv to reach delegate natively, got msg=%q kv=%v", gotMsg, gotKV)
+	}
+}